@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/peer-calls/peer-calls/server/message"
+)
+
+// Writer is the minimal transport a Client writes outbound messages to: a
+// WebSocket connection in production, an in-memory channel in tests. Which
+// wire format a Writer uses (the bespoke envelope or a JSON-RPC 2.0 frame)
+// is decided when it is constructed, not by Client.
+type Writer interface {
+	Write(msg message.Message) error
+}
+
+// Client represents one signaling connection. It embeds a resumer so every
+// message written to it (already stamped with its room sequence number by
+// the Adapter that sent it) is buffered for replay: a client that
+// reconnects can call Resume to replay whatever it missed, and Ack to let
+// the buffer drop entries it no longer needs.
+type Client struct {
+	id     identifiers.ClientID
+	writer Writer
+	log    logger.Service
+
+	*resumer
+
+	mu       sync.Mutex
+	metadata string
+	err      error
+}
+
+// NewClient creates a Client that writes outbound messages to writer. A nil
+// log defaults to logger.NewNop().
+func NewClient(writer Writer, log logger.Service) *Client {
+	if log == nil {
+		log = logger.NewNop()
+	}
+
+	c := &Client{
+		writer:  writer,
+		resumer: newResumer(),
+	}
+
+	c.id = identifiers.NewClientID()
+	c.log = log.With(logger.String("clientId", string(c.id)))
+
+	return c
+}
+
+// ID returns the client's unique ID.
+func (c *Client) ID() identifiers.ClientID {
+	return c.id
+}
+
+// SetMetadata sets the free-form metadata an Adapter reports for this
+// client from Clients().
+func (c *Client) SetMetadata(metadata string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metadata = metadata
+}
+
+// Metadata returns the metadata last set via SetMetadata.
+func (c *Client) Metadata() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.metadata
+}
+
+// Write buffers msg for replay and hands it to the underlying Writer. msg
+// is expected to already carry its room sequence number, stamped once by
+// the Adapter that is fanning it out to every recipient.
+func (c *Client) Write(msg message.Message) error {
+	c.record(msg)
+
+	return errors.Trace(c.writer.Write(msg))
+}
+
+// Resume returns the messages buffered for this client after req.LastSeq,
+// for a reconnect handler to replay before resuming live traffic. ok is
+// false when the gap is too large to close from the buffer, meaning the
+// caller must reset the client (e.g. drop it back to a fresh room join)
+// instead of replaying to it.
+func (c *Client) Resume(req ResumeRequest) ([]message.Message, bool) {
+	return c.resumer.resume(req)
+}
+
+// Ack records that the client has seen every message up to a.Seq, allowing
+// the replay buffer to drop entries it no longer needs.
+func (c *Client) Ack(a Ack) {
+	c.resumer.ack(a)
+}
+
+// ShouldReset reports whether the client has gone unacked for longer than
+// the replay window allows and must be forcibly reset by the reconnect
+// handler instead of resumed.
+func (c *Client) ShouldReset() bool {
+	return c.resumer.shouldReset()
+}
+
+// setErr records the terminal error for this client, surfaced by Err.
+func (c *Client) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err == nil {
+		c.err = err
+		c.log.Debug("client disconnected", logger.Err(err))
+	}
+}
+
+// Err returns the error that ended this client's Subscribe loop, if any.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Subscribe returns a channel for this client's inbound messages. Client
+// itself sends nothing on it; the channel is closed and Err is set once ctx
+// is cancelled.
+func (c *Client) Subscribe(ctx context.Context) <-chan message.Message {
+	msgCh := make(chan message.Message)
+
+	go func() {
+		defer close(msgCh)
+
+		<-ctx.Done()
+		c.setErr(errors.Trace(ctx.Err()))
+	}()
+
+	return msgCh
+}
@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/juju/errors"
+	"github.com/peer-calls/peer-calls/server/logger"
 	"github.com/spf13/pflag"
 )
 
@@ -67,6 +68,18 @@ type Params struct {
 	FlagRegistry      FlagRegistry
 	Handler           Handler
 	SubCommands       []*Command
+	// PluginLoader, when set, is consulted for a subcommand not found among
+	// SubCommands before ErrCommandNotFound is returned.
+	PluginLoader *PluginLoader
+	// Logger receives diagnostics for this command. Defaults to
+	// logger.NewNop() so embedders who never set it get silence rather than
+	// stray stderr writes.
+	Logger logger.Service
+	// LogFlags, when set on the root command, causes Exec to register
+	// --log-format/--log-level and rebuild Logger from their parsed values
+	// before running the handler, so the flags affect this command and
+	// every subcommand it recurses into that does not set its own Logger.
+	LogFlags *logger.Flags
 }
 
 func New(params Params) *Command {
@@ -80,6 +93,10 @@ func New(params Params) *Command {
 		}
 	}
 
+	if params.Logger == nil {
+		params.Logger = logger.NewNop()
+	}
+
 	return &Command{
 		params:      params,
 		subCommands: subCommands,
@@ -91,6 +108,12 @@ func (c Command) Name() string {
 }
 
 func (c *Command) Exec(ctx context.Context, args []string) error {
+	if c.params.PluginLoader != nil {
+		// Subcommands discovered but never invoked during this Exec still hold
+		// a live plugin process; Close kills every one of them once we're done.
+		defer c.params.PluginLoader.Close()
+	}
+
 	doneCh := make(chan struct{})
 	defer func() {
 		<-doneCh
@@ -99,6 +122,16 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// Seed ctx with the static default Logger so anything logging through
+	// logger.FromContext before LogFlags.Build overrides it below (e.g. an
+	// interrupt during flag parsing) still reaches a real Service instead of
+	// silently falling back to Nop. A subcommand left on the default Nop
+	// keeps the logger its parent already put on ctx, so the parent's
+	// --log-format/--log-level reach it too.
+	if c.params.Logger != logger.NewNop() || logger.FromContext(ctx) == logger.NewNop() {
+		ctx = logger.WithLogger(ctx, c.params.Logger)
+	}
+
 	// Register a channel for interrupts so we can cancel the above context.
 	interruptCh := make(chan os.Signal, 1)
 	signal.Notify(interruptCh, syscall.SIGINT, syscall.SIGTERM)
@@ -112,6 +145,11 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 		select {
 		case <-ctx.Done():
 		case <-interruptCh:
+			// ctx may by now carry the logger built from --log-format/
+			// --log-level below; c.params.Logger is only ever the static
+			// default passed to New, so logging through it here would
+			// silently ignore those flags.
+			logger.FromContext(ctx).Info("received interrupt signal, shutting down")
 			cancel()
 		}
 	}()
@@ -129,6 +167,10 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 		c.params.FlagRegistry.RegisterFlags(c, flags)
 	}
 
+	if c.params.LogFlags != nil {
+		c.params.LogFlags.RegisterFlags(flags)
+	}
+
 	err := flags.Parse(args)
 	if err != nil {
 		return errors.Annotatef(err, "parse args for command: %s", c.params.Name)
@@ -136,6 +178,15 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 
 	args = flags.Args()
 
+	if c.params.LogFlags != nil {
+		log, err := c.params.LogFlags.Build()
+		if err != nil {
+			return errors.Annotatef(err, "build logger for command: %s", c.params.Name)
+		}
+
+		ctx = logger.WithLogger(ctx, log)
+	}
+
 	if c.params.Handler != nil {
 		err = c.params.Handler.Handle(ctx, args)
 		if err != nil {
@@ -151,10 +202,24 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 		args = args[1:]
 	}
 
-	if len(args) > 0 && len(c.subCommands) > 0 {
+	if len(args) > 0 && (len(c.subCommands) > 0 || c.params.PluginLoader != nil) {
 		subName := args[0]
+
 		subCommand, ok := c.subCommands[subName]
 		if !ok {
+			var err error
+
+			subCommand, err = c.loadPlugin(subName)
+			if err != nil {
+				return errors.Annotatef(err, "command: %s", subName)
+			}
+
+			ok = subCommand != nil
+		}
+
+		if !ok {
+			logger.FromContext(ctx).Error("command not found", logger.String("command", subName))
+
 			return errors.Annotatef(ErrCommandNotFound, "command: %s", subName)
 		}
 
@@ -166,3 +231,31 @@ func (c *Command) Exec(ctx context.Context, args []string) error {
 
 	return nil
 }
+
+// loadPlugin looks for subName among the executables in
+// c.params.PluginLoader's directory. It returns (nil, nil) when no plugin
+// claims subName and every plugin loaded cleanly — the caller then reports
+// plain ErrCommandNotFound. When no plugin claims subName and any entry in
+// the directory failed to load (or the directory could not be read), it
+// returns an error wrapping ErrPluginLaunch. A binary that fails the
+// handshake reports no name, so that error is returned whether or not the
+// failed binary was the one meant to serve subName.
+func (c *Command) loadPlugin(subName string) (*Command, error) {
+	if c.params.PluginLoader == nil {
+		return nil, nil
+	}
+
+	commands, loadErrs := c.params.PluginLoader.Load()
+
+	for _, cmd := range commands {
+		if cmd.Name() == subName {
+			return cmd, nil
+		}
+	}
+
+	if len(loadErrs) > 0 {
+		return nil, errors.Annotatef(ErrPluginLaunch, "%s: %s", subName, loadErrs)
+	}
+
+	return nil, nil
+}
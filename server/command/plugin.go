@@ -0,0 +1,150 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/juju/errors"
+)
+
+// ErrPluginLaunch is the cause wrapped by ErrCommandNotFound-shaped errors
+// when a plugin binary exists but fails the handshake, so callers can tell
+// "not built in and no plugin" apart from "plugin failed to launch".
+var ErrPluginLaunch = errors.New("plugin failed to launch")
+
+// pluginHandshake is the shared handshake every peer-calls plugin binary
+// must answer the same way, following the hashicorp/go-plugin convention
+// of using it to make sure we're talking to a plugin meant for us and not
+// some other RPC server.
+var pluginHandshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "PEERCALLS_PLUGIN",
+	MagicCookieValue: "peer-calls",
+}
+
+// SubCommand is the interface a subcommand plugin must expose over RPC. It
+// mirrors Handler.Handle plus the bits of Params needed to register the
+// plugin as a synthetic *Command.
+type SubCommand interface {
+	Name() string
+	Desc() string
+	Handle(ctx context.Context, args []string) error
+}
+
+// pluginMap is the hashicorp/go-plugin plugin set every peer-calls plugin
+// binary is expected to serve under the "subcommand" key.
+var pluginMap = map[string]plugin.Plugin{
+	"subcommand": &subCommandPlugin{},
+}
+
+// PluginLoader discovers *Command instances from external executables
+// found in Dir, so SubCommands can grow at runtime without recompiling the
+// peer-calls binary. A PluginLoader only ever launches each plugin binary
+// once: the first Load caches the result (commands, errors and the
+// *plugin.Client each command is backed by) so that resolving several
+// subcommands one after another doesn't relaunch every other binary in Dir
+// each time. Call Close when the loader is no longer needed so plugins that
+// were discovered but never invoked don't leak their child process.
+type PluginLoader struct {
+	// Dir is scanned for plugin binaries, e.g. set from
+	// $PEERCALLS_PLUGIN_DIR.
+	Dir string
+
+	once     sync.Once
+	commands []*Command
+	loadErrs []error
+	clients  []*plugin.Client
+}
+
+// Load scans Dir for executables, performs the plugin handshake with each,
+// and returns one *Command per plugin that answered it, registered under
+// the name the plugin reports. A plugin that fails to launch is skipped
+// with its error collected rather than aborting the whole load. Only the
+// first call actually touches the filesystem or launches anything;
+// subsequent calls return the cached result.
+func (l *PluginLoader) Load() ([]*Command, []error) {
+	l.once.Do(l.load)
+
+	return l.commands, l.loadErrs
+}
+
+func (l *PluginLoader) load() {
+	if l.Dir == "" {
+		return
+	}
+
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		l.loadErrs = append(l.loadErrs, errors.Annotate(err, "plugin loader: read dir"))
+
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(l.Dir, entry.Name())
+
+		cmd, client, err := l.loadOne(path)
+		if err != nil {
+			l.loadErrs = append(l.loadErrs, errors.Annotatef(ErrPluginLaunch, "%s: %s", path, err))
+
+			continue
+		}
+
+		l.clients = append(l.clients, client)
+		l.commands = append(l.commands, cmd)
+	}
+}
+
+func (l *PluginLoader) loadOne(path string) (*Command, *plugin.Client, error) {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: pluginHandshake,
+		Plugins:         pluginMap,
+		Cmd:             pluginCmd(path),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+
+		return nil, nil, errors.Annotate(err, "connect")
+	}
+
+	raw, err := rpcClient.Dispense("subcommand")
+	if err != nil {
+		client.Kill()
+
+		return nil, nil, errors.Annotate(err, "dispense")
+	}
+
+	sub, ok := raw.(SubCommand)
+	if !ok {
+		client.Kill()
+
+		return nil, nil, errors.New("plugin does not implement SubCommand")
+	}
+
+	cmd := New(Params{
+		Name: sub.Name(),
+		Desc: sub.Desc(),
+		Handler: HandlerFunc(func(ctx context.Context, args []string) error {
+			return errors.Trace(sub.Handle(ctx, args))
+		}),
+	})
+
+	return cmd, client, nil
+}
+
+// Close kills every plugin process this loader launched, including ones
+// that were discovered but never invoked through their Command's Handler.
+func (l *PluginLoader) Close() {
+	for _, client := range l.clients {
+		client.Kill()
+	}
+}
@@ -0,0 +1,140 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"net/rpc"
+	"os/exec"
+	"sync"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// pluginCmd builds the *exec.Cmd used to launch a plugin binary found by
+// PluginLoader. It is a function, rather than inlined, purely so tests can
+// stub it out without shelling out to a real binary.
+var pluginCmd = func(path string) *exec.Cmd {
+	return exec.Command(path)
+}
+
+// subCommandPlugin implements plugin.Plugin, wiring SubCommand into
+// hashicorp/go-plugin's net/rpc transport. Impl is only set on the plugin
+// binary side; the host side only ever calls Client.
+type subCommandPlugin struct {
+	Impl SubCommand
+}
+
+func (p *subCommandPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &subCommandRPCServer{impl: p.Impl}, nil
+}
+
+func (p *subCommandPlugin) Client(_ *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &subCommandRPCClient{client: c}, nil
+}
+
+// subCommandRPCServer runs inside the plugin binary and dispatches RPC
+// calls to the real SubCommand implementation. net/rpc cannot carry a
+// context.Context across the wire, so Handle builds its own and stores its
+// CancelFunc; Cancel, triggered by the host's ctx.Done(), calls it. This
+// way any SubCommand that only implements the documented Handle(ctx, args)
+// method gets a ctx that is actually cancelled when the host's is, without
+// needing to declare anything beyond the published interface.
+type subCommandRPCServer struct {
+	impl SubCommand
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (s *subCommandRPCServer) Name(_ interface{}, resp *string) error {
+	*resp = s.impl.Name()
+
+	return nil
+}
+
+func (s *subCommandRPCServer) Desc(_ interface{}, resp *string) error {
+	*resp = s.impl.Desc()
+
+	return nil
+}
+
+func (s *subCommandRPCServer) Handle(args []string, resp *string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	defer cancel()
+
+	err := s.impl.Handle(ctx, args)
+	if err != nil {
+		*resp = err.Error()
+	}
+
+	return nil
+}
+
+// Cancel is invoked by the host when the context passed to Handle is
+// cancelled (e.g. by SIGINT/SIGTERM), so a plugin subcommand shuts down
+// via its own ctx instead of being force-killed.
+func (s *subCommandRPCServer) Cancel(_ interface{}, _ *interface{}) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	return nil
+}
+
+// subCommandRPCClient runs on the host side and implements SubCommand by
+// forwarding every call over RPC to the plugin binary.
+type subCommandRPCClient struct {
+	client *rpc.Client
+}
+
+func (c *subCommandRPCClient) Name() string {
+	var resp string
+
+	c.client.Call("Plugin.Name", new(interface{}), &resp)
+
+	return resp
+}
+
+func (c *subCommandRPCClient) Desc() string {
+	var resp string
+
+	c.client.Call("Plugin.Desc", new(interface{}), &resp)
+
+	return resp
+}
+
+// Handle forwards args to the plugin and blocks until it returns, while a
+// background goroutine translates ctx cancellation into a Cancel RPC call
+// so the plugin side can stop early instead of being force-killed.
+func (c *subCommandRPCClient) Handle(ctx context.Context, args []string) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.client.Call("Plugin.Cancel", new(interface{}), new(interface{}))
+		case <-done:
+		}
+	}()
+
+	var errMsg string
+	if err := c.client.Call("Plugin.Handle", args, &errMsg); err != nil {
+		return err
+	}
+
+	if errMsg != "" {
+		return errors.New(errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginLoader_emptyDir(t *testing.T) {
+	loader := PluginLoader{Dir: t.TempDir()}
+
+	commands, errs := loader.Load()
+	assert.Empty(t, commands)
+	assert.Empty(t, errs)
+}
+
+func TestPluginLoader_noDirConfigured(t *testing.T) {
+	loader := PluginLoader{}
+
+	commands, errs := loader.Load()
+	assert.Nil(t, commands)
+	assert.Nil(t, errs)
+}
+
+func TestCommand_loadPlugin_noLoader(t *testing.T) {
+	cmd := New(Params{Name: "root"})
+
+	found, err := cmd.loadPlugin("missing")
+	assert.Nil(t, found)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,72 @@
+package server_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/require"
+)
+
+// room is the RoomID shared by every server_test package test that doesn't care
+// about a specific value.
+var room = identifiers.RoomID("test-room")
+
+// MockWriter is a Writer that pushes every serialized Message onto out
+// instead of writing to a real connection, so a test can assert on exactly
+// what would have been sent over the wire.
+type MockWriter struct {
+	out chan []byte
+}
+
+// mockWriterBuffer is large enough that no test needs a concurrent reader
+// just to keep an Adapter call (which writes to every recipient in the same
+// goroutine) from blocking before the test gets a chance to read any of it.
+const mockWriterBuffer = 16
+
+// NewMockWriter creates a MockWriter.
+func NewMockWriter() *MockWriter {
+	return &MockWriter{out: make(chan []byte, mockWriterBuffer)}
+}
+
+// Write implements server.Writer by serializing msg the same way serialize
+// does and sending it on out.
+func (w *MockWriter) Write(msg message.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	w.out <- data
+
+	return nil
+}
+
+// serialize JSON-encodes msg the same way MockWriter.Write does, so a test
+// can build the bytes it expects a MockWriter to have received.
+func serialize(t *testing.T, msg message.Message) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	return data
+}
+
+// deserialize reverses serialize/MockWriter.Write.
+func deserialize(t *testing.T, data []byte) message.Message {
+	t.Helper()
+
+	var msg message.Message
+	require.NoError(t, json.Unmarshal(data, &msg))
+
+	return msg
+}
+
+// errIs reports whether err is exactly target, following the same
+// errors.Cause(err) == sentinel convention used outside of tests (see
+// natsadapter.go).
+func errIs(err, target error) bool {
+	return err == target
+}
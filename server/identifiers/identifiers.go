@@ -0,0 +1,26 @@
+// Package identifiers defines the ID types shared across the server and
+// message packages, so a room or client can be referred to the same way
+// regardless of which Adapter backs it.
+package identifiers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RoomID identifies a signaling room.
+type RoomID string
+
+// ClientID uniquely identifies one signaling connection within a room.
+type ClientID string
+
+// NewClientID generates a random ClientID.
+func NewClientID() ClientID {
+	var b [8]byte
+
+	// crypto/rand.Read never returns a non-nil error on the platforms Peer
+	// Calls targets; it only fails if the OS entropy source is unavailable.
+	_, _ = rand.Read(b[:])
+
+	return ClientID(hex.EncodeToString(b[:]))
+}
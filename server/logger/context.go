@@ -0,0 +1,22 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying log as the logger future calls
+// should use, so per-request fields attached via Service.With propagate to
+// every downstream call without threading a Service parameter everywhere.
+func WithLogger(ctx context.Context, log Service) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns the Service attached to ctx by WithLogger, or a Nop
+// Service if none was attached.
+func FromContext(ctx context.Context) Service {
+	if log, ok := ctx.Value(contextKey{}).(Service); ok {
+		return log
+	}
+
+	return NewNop()
+}
@@ -0,0 +1,30 @@
+package logger
+
+import "github.com/spf13/pflag"
+
+// Flags holds the raw --log-format/--log-level values so a Service can be
+// built from them once the command package has parsed its flags.
+type Flags struct {
+	Format string
+	Level  string
+}
+
+// RegisterFlags registers --log-format and --log-level into flags. It is
+// exported separately from command.FlagRegistry so this package does not
+// need to depend on command.
+func (f *Flags) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.Format, "log-format", "text", "log output format: text, json or syslog")
+	flags.StringVar(&f.Level, "log-level", "info", "minimum log level: debug, info, warn or error")
+}
+
+// Build constructs a Service from the parsed flag values.
+func (f *Flags) Build() (Service, error) {
+	switch f.Format {
+	case "json":
+		return NewZap(f.Level, true)
+	case "syslog":
+		return NewSyslog(f.Level)
+	default:
+		return NewZap(f.Level, false)
+	}
+}
@@ -0,0 +1,42 @@
+// Package logger provides a single structured logging abstraction used by
+// the server and command packages, so embedders can silence or redirect
+// Peer Calls diagnostics to whichever sink they prefer instead of every
+// package writing to stderr directly.
+package logger
+
+// Field is one structured key/value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String builds a Field from a string value.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int builds a Field from an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err builds a Field from an error, under the conventional "error" key.
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Service is the logging interface threaded through the server and command
+// packages. Implementations must be safe for concurrent use.
+type Service interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	Errorf(format string, args ...interface{})
+
+	// With returns a child Service that attaches fields to every entry it
+	// logs, in addition to whatever the parent already attaches. Used to
+	// pin per-request/per-room fields like roomID or clientID once and have
+	// them inherited by every downstream call.
+	With(fields ...Field) Service
+}
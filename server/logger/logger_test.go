@@ -0,0 +1,26 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNop_doesNotPanic(t *testing.T) {
+	log := logger.NewNop()
+	log.With(logger.String("room", "r1")).Info("hello", logger.Int("n", 1))
+	log.Errorf("boom: %s", "oops")
+}
+
+func TestContext_defaultsToNop(t *testing.T) {
+	log := logger.FromContext(context.Background())
+	assert.NotNil(t, log)
+}
+
+func TestContext_roundTrip(t *testing.T) {
+	want := logger.NewNop()
+	ctx := logger.WithLogger(context.Background(), want)
+	assert.Equal(t, want, logger.FromContext(ctx))
+}
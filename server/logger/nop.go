@@ -0,0 +1,20 @@
+package logger
+
+// nop is a Service that discards everything. It is the default for tests
+// such as TestMemoryAdapter_* that must stay silent.
+type nop struct{}
+
+// NewNop returns a Service that discards all log entries.
+func NewNop() Service {
+	return nop{}
+}
+
+func (nop) Debug(string, ...Field) {}
+func (nop) Info(string, ...Field)  {}
+func (nop) Warn(string, ...Field)  {}
+func (nop) Error(string, ...Field) {}
+func (nop) Errorf(string, ...interface{}) {}
+
+func (n nop) With(...Field) Service {
+	return n
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+
+	"github.com/juju/errors"
+)
+
+// syslogService adapts a *syslog.Writer to Service. Syslog has no notion of
+// structured fields, so they are rendered as "key=value" pairs appended to
+// the message.
+type syslogService struct {
+	writer *syslog.Writer
+	fields []Field
+}
+
+// NewSyslog builds a Service that writes to the local syslog/journald
+// daemon under the "peer-calls" tag. level is accepted for symmetry with
+// NewZap but syslog priorities are fixed per method (Debug/Info/...).
+func NewSyslog(level string) (Service, error) {
+	w, err := syslog.New(syslog.LOG_INFO, "peer-calls")
+	if err != nil {
+		return nil, errors.Annotate(err, "logger: connect to syslog")
+	}
+
+	return syslogService{writer: w}, nil
+}
+
+func (s syslogService) render(msg string, fields []Field) string {
+	for _, f := range append(append([]Field{}, s.fields...), fields...) {
+		msg += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+
+	return msg
+}
+
+func (s syslogService) Debug(msg string, fields ...Field) {
+	s.writer.Debug(s.render(msg, fields))
+}
+
+func (s syslogService) Info(msg string, fields ...Field) {
+	s.writer.Info(s.render(msg, fields))
+}
+
+func (s syslogService) Warn(msg string, fields ...Field) {
+	s.writer.Warning(s.render(msg, fields))
+}
+
+func (s syslogService) Error(msg string, fields ...Field) {
+	s.writer.Err(s.render(msg, fields))
+}
+
+func (s syslogService) Errorf(format string, args ...interface{}) {
+	s.writer.Err(fmt.Sprintf(format, args...))
+}
+
+func (s syslogService) With(fields ...Field) Service {
+	return syslogService{writer: s.writer, fields: append(append([]Field{}, s.fields...), fields...)}
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"github.com/juju/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// zapService adapts a *zap.SugaredLogger to Service.
+type zapService struct {
+	log *zap.SugaredLogger
+}
+
+// NewZap builds a Service backed by zap, writing to stderr. level is one of
+// "debug", "info", "warn" or "error". When json is true, entries are
+// encoded as JSON; otherwise a human-readable console format is used.
+func NewZap(level string, json bool) (Service, error) {
+	var lvl zapcore.Level
+	if err := lvl.Set(level); err != nil {
+		return nil, errors.Annotatef(err, "logger: parse level: %s", level)
+	}
+
+	cfg := zap.NewProductionConfig()
+	if !json {
+		cfg = zap.NewDevelopmentConfig()
+	}
+
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+
+	zl, err := cfg.Build()
+	if err != nil {
+		return nil, errors.Annotate(err, "logger: build zap logger")
+	}
+
+	return zapService{log: zl.Sugar()}, nil
+}
+
+func fieldsToArgs(fields []Field) []interface{} {
+	args := make([]interface{}, 0, len(fields)*2)
+
+	for _, f := range fields {
+		args = append(args, f.Key, f.Value)
+	}
+
+	return args
+}
+
+func (s zapService) Debug(msg string, fields ...Field) {
+	s.log.Debugw(msg, fieldsToArgs(fields)...)
+}
+
+func (s zapService) Info(msg string, fields ...Field) {
+	s.log.Infow(msg, fieldsToArgs(fields)...)
+}
+
+func (s zapService) Warn(msg string, fields ...Field) {
+	s.log.Warnw(msg, fieldsToArgs(fields)...)
+}
+
+func (s zapService) Error(msg string, fields ...Field) {
+	s.log.Errorw(msg, fieldsToArgs(fields)...)
+}
+
+func (s zapService) Errorf(format string, args ...interface{}) {
+	s.log.Errorf(format, args...)
+}
+
+func (s zapService) With(fields ...Field) Service {
+	return zapService{log: s.log.With(fieldsToArgs(fields)...)}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/peer-calls/peer-calls/server/message"
+)
+
+// MemoryAdapter is an Adapter implementation that keeps room membership and
+// message fan-out entirely in process memory. It is the default Adapter for
+// a single-node deployment; NATSAdapter is used instead once a room needs
+// to span more than one signaling node.
+type MemoryAdapter struct {
+	room identifiers.RoomID
+	log  logger.Service
+	seq  *roomSequencer
+
+	mu      sync.RWMutex
+	clients map[identifiers.ClientID]*Client
+}
+
+// NewMemoryAdapter creates a new MemoryAdapter for room. A nil log defaults
+// to logger.NewNop().
+func NewMemoryAdapter(room identifiers.RoomID, log logger.Service) *MemoryAdapter {
+	if log == nil {
+		log = logger.NewNop()
+	}
+
+	return &MemoryAdapter{
+		room:    room,
+		log:     log.With(logger.String("room", string(room))),
+		seq:     newRoomSequencer(),
+		clients: map[identifiers.ClientID]*Client{},
+	}
+}
+
+// Add registers client as a member of the room and broadcasts a RoomJoin
+// announcement for client to every member, including client itself. client
+// is not sent announcements for the members that joined before it.
+func (a *MemoryAdapter) Add(client *Client) error {
+	a.mu.Lock()
+	a.clients[client.ID()] = client
+	a.mu.Unlock()
+
+	a.log.Info("client added", logger.String("clientId", string(client.ID())))
+
+	return a.Broadcast(message.NewRoomJoin(a.room, message.RoomJoin{
+		ClientID: client.ID(),
+		Metadata: client.Metadata(),
+	}))
+}
+
+// Remove removes clientID from the room.
+func (a *MemoryAdapter) Remove(clientID identifiers.ClientID) error {
+	a.mu.Lock()
+	delete(a.clients, clientID)
+	a.mu.Unlock()
+
+	a.log.Info("client removed", logger.String("clientId", string(clientID)))
+
+	return nil
+}
+
+// Metadata returns the metadata of clientID, or ok=false if it is not a
+// member of the room.
+func (a *MemoryAdapter) Metadata(clientID identifiers.ClientID) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	client, ok := a.clients[clientID]
+	if !ok {
+		return "", false
+	}
+
+	return client.Metadata(), true
+}
+
+// Clients returns the metadata of every member of the room, keyed by
+// client ID.
+func (a *MemoryAdapter) Clients() (map[identifiers.ClientID]string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	clientIDs := make(map[identifiers.ClientID]string, len(a.clients))
+
+	for id, client := range a.clients {
+		clientIDs[id] = client.Metadata()
+	}
+
+	return clientIDs, nil
+}
+
+// Size returns the number of clients in the room.
+func (a *MemoryAdapter) Size() (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return len(a.clients), nil
+}
+
+// Emit delivers msg to clientID, stamped with the next per-room sequence
+// number. It is a no-op if clientID is not a member of the room.
+func (a *MemoryAdapter) Emit(clientID identifiers.ClientID, msg message.Message) error {
+	a.mu.RLock()
+	client, ok := a.clients[clientID]
+	a.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	var err error
+
+	a.seq.next(func(seq uint64) {
+		msg.Seq = seq
+		err = client.Write(msg)
+	})
+
+	return err
+}
+
+// Broadcast delivers msg to every member of the room. msg is stamped with a
+// single per-room sequence number before fan-out, and the whole fan-out
+// happens while that sequence number is still held, so two concurrent
+// Broadcast/Emit calls can never interleave their writes to the same
+// client out of seq order.
+func (a *MemoryAdapter) Broadcast(msg message.Message) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var err error
+
+	a.seq.next(func(seq uint64) {
+		msg.Seq = seq
+
+		for _, client := range a.clients {
+			if werr := client.Write(msg); werr != nil {
+				err = werr
+				return
+			}
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,48 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryAdapter_Broadcast_jsonrpc checks that the RoomJoin announcements
+// Broadcast fans out on each Add encode as JSON-RPC 2.0 "room.join"
+// notifications. The clients write the bespoke envelope through MockWriter;
+// the test re-encodes every delivered message with a JSONRPCEncoder. Each
+// Add reaches every current member, same as asserted by
+// TestMemoryAdapter_Broadcast.
+func TestMemoryAdapter_Broadcast_jsonrpc(t *testing.T) {
+	adapter := server.NewMemoryAdapter(room, logger.NewNop())
+
+	mockWriter1 := NewMockWriter()
+	client1 := server.NewClient(mockWriter1, logger.NewNop())
+	defer close(mockWriter1.out)
+
+	mockWriter2 := NewMockWriter()
+	client2 := server.NewClient(mockWriter2, logger.NewNop())
+	defer close(mockWriter2.out)
+
+	require.NoError(t, adapter.Add(client1))
+	require.NoError(t, adapter.Add(client2))
+
+	enc := message.NewJSONRPCEncoder()
+
+	// client1 was already a member when client2 joined, so it hears both
+	// announcements; client2 only hears the one announcing itself.
+	for i := 0; i < 2; i++ {
+		joinMsg := <-mockWriter1.out
+		frame, err := enc.Encode(deserialize(t, joinMsg))
+		require.NoError(t, err)
+		assert.Contains(t, string(frame), `"method":"room.join"`)
+	}
+
+	joinMsg := <-mockWriter2.out
+	frame, err := enc.Encode(deserialize(t, joinMsg))
+	require.NoError(t, err)
+	assert.Contains(t, string(frame), `"method":"room.join"`)
+}
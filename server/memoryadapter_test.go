@@ -8,6 +8,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/peer-calls/peer-calls/server"
 	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
 	"github.com/peer-calls/peer-calls/server/message"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/goleak"
@@ -15,9 +16,9 @@ import (
 
 func TestMemoryAdapter_add_remove_clients(t *testing.T) {
 	goleak.VerifyNone(t)
-	adapter := server.NewMemoryAdapter(room)
+	adapter := server.NewMemoryAdapter(room, logger.NewNop())
 	mockWriter := NewMockWriter()
-	client := server.NewClient(mockWriter)
+	client := server.NewClient(mockWriter, logger.NewNop())
 	client.SetMetadata("a")
 	clientID := client.ID()
 	err := adapter.Add(client)
@@ -40,10 +41,10 @@ func TestMemoryAdapter_add_remove_clients(t *testing.T) {
 
 func TestMemoryAdapter_emitFound(t *testing.T) {
 	goleak.VerifyNone(t)
-	adapter := server.NewMemoryAdapter(room)
+	adapter := server.NewMemoryAdapter(room, logger.NewNop())
 	mockWriter := NewMockWriter()
 	defer close(mockWriter.out)
-	client := server.NewClient(mockWriter)
+	client := server.NewClient(mockWriter, logger.NewNop())
 	adapter.Add(client)
 	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
@@ -63,21 +64,23 @@ func TestMemoryAdapter_emitFound(t *testing.T) {
 	adapter.Emit(client.ID(), msg)
 	msg1 := <-mockWriter.out
 
-	joinMessage := serialize(t, message.NewRoomJoin(room, message.RoomJoin{
+	expectedJoin := message.NewRoomJoin(room, message.RoomJoin{
 		ClientID: client.ID(),
 		Metadata: client.Metadata(),
-	}))
+	})
+	expectedJoin.Seq = 1 // stamped by Add's Broadcast, the first call on this room.
 
-	assert.Equal(t, joinMessage, msg1)
+	assert.Equal(t, serialize(t, expectedJoin), msg1)
 	msg2 := <-mockWriter.out
 	cancel()
+	msg.Seq = 2 // stamped by this Emit, the second call on this room.
 	assert.Equal(t, serialize(t, msg), msg2)
 	wg.Wait()
 }
 
 func TestMemoryAdapter_emitMissing(t *testing.T) {
 	goleak.VerifyNone(t)
-	adapter := server.NewMemoryAdapter(room)
+	adapter := server.NewMemoryAdapter(room, logger.NewNop())
 
 	msg := message.NewReady(room, message.Ready{
 		Nickname: "test",
@@ -88,11 +91,11 @@ func TestMemoryAdapter_emitMissing(t *testing.T) {
 
 func TestMemoryAdapter_Broadcast(t *testing.T) {
 	goleak.VerifyNone(t)
-	adapter := server.NewMemoryAdapter(room)
+	adapter := server.NewMemoryAdapter(room, logger.NewNop())
 	mockWriter1 := NewMockWriter()
-	client1 := server.NewClient(mockWriter1)
+	client1 := server.NewClient(mockWriter1, logger.NewNop())
 	mockWriter2 := NewMockWriter()
-	client2 := server.NewClient(mockWriter2)
+	client2 := server.NewClient(mockWriter2, logger.NewNop())
 	defer close(mockWriter1.out)
 	defer close(mockWriter2.out)
 	assert.Nil(t, adapter.Add(client1))
@@ -122,9 +125,18 @@ func TestMemoryAdapter_Broadcast(t *testing.T) {
 	})
 	adapter.Broadcast(msg)
 
-	assert.Equal(t, serialize(t, message.NewRoomJoin(room, message.RoomJoin{client1.ID(), ""})), <-mockWriter1.out)
-	assert.Equal(t, serialize(t, message.NewRoomJoin(room, message.RoomJoin{client2.ID(), ""})), <-mockWriter1.out)
-	assert.Equal(t, serialize(t, message.NewRoomJoin(room, message.RoomJoin{client2.ID(), ""})), <-mockWriter2.out)
+	// Add(client1) and Add(client2) each stamp their own RoomJoin broadcast
+	// with the next per-room sequence number, 1 and 2 respectively; this
+	// Broadcast is the room's third call, so msg is stamped with 3.
+	join1 := message.NewRoomJoin(room, message.RoomJoin{ClientID: client1.ID()})
+	join1.Seq = 1
+	join2 := message.NewRoomJoin(room, message.RoomJoin{ClientID: client2.ID()})
+	join2.Seq = 2
+	msg.Seq = 3
+
+	assert.Equal(t, serialize(t, join1), <-mockWriter1.out)
+	assert.Equal(t, serialize(t, join2), <-mockWriter1.out)
+	assert.Equal(t, serialize(t, join2), <-mockWriter2.out)
 
 	serializedMsg := serialize(t, msg)
 	assert.Equal(t, serializedMsg, <-mockWriter1.out)
@@ -0,0 +1,197 @@
+package message
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// jsonRPCVersion is the only protocol version this codec understands.
+const jsonRPCVersion = "2.0"
+
+// ErrJSONRPCVersion is returned when decoding a frame that does not declare
+// "jsonrpc": "2.0".
+var ErrJSONRPCVersion = errors.New("unsupported jsonrpc version")
+
+// jsonRPCMethods maps the internal Message.Type values to the method name
+// used on the wire, and back. Every type handled by NewReady, NewRoomJoin,
+// etc. must have an entry here for the JSON-RPC codec to round-trip it.
+var jsonRPCMethods = map[Type]string{
+	TypeReady:    "ready",
+	TypeRoomJoin: "room.join",
+	TypeHangUp:   "hangUp",
+	TypeSignal:   "signal",
+	TypePing:     "ping",
+}
+
+var jsonRPCTypes = func() map[string]Type {
+	types := make(map[string]Type, len(jsonRPCMethods))
+	for typ, method := range jsonRPCMethods {
+		types[method] = typ
+	}
+
+	return types
+}()
+
+// jsonRPCFrame is the wire representation of a JSON-RPC 2.0 request,
+// response or notification. Which fields are set distinguishes the three:
+// a request/notification has Method set, a response has Result or Error
+// set, and a notification (as opposed to a request) has no ID.
+type jsonRPCFrame struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *uint64         `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSONRPCEncoder encodes Messages as JSON-RPC 2.0 frames. A Message emitted
+// in reply to a request that is still awaiting an answer is sent as a
+// "result" response carrying that request's id; every other Message is sent
+// as a server-initiated notification.
+//
+// JSONRPCEncoder is safe for concurrent use.
+type JSONRPCEncoder struct {
+	mu sync.Mutex
+	// pending holds, per Message.Type, the ids of requests awaiting a reply
+	// in the order they arrived. A client can have more than one request of
+	// the same Type in flight at once (e.g. two "signal" requests during ICE
+	// candidate exchange), so this must be a queue, not a single scalar: the
+	// oldest outstanding id of a given type is always the next one answered.
+	pending map[Type][]uint64
+}
+
+// NewJSONRPCEncoder creates a new, empty JSONRPCEncoder.
+func NewJSONRPCEncoder() *JSONRPCEncoder {
+	return &JSONRPCEncoder{
+		pending: map[Type][]uint64{},
+	}
+}
+
+// AwaitReply records that a request with id is awaiting a reply whose
+// Message.Type is typ, so the next Encode of that type is sent as a
+// response rather than a notification. Calling AwaitReply again for the
+// same typ before the first reply is sent queues id behind the earlier one
+// instead of replacing it.
+func (e *JSONRPCEncoder) AwaitReply(typ Type, id uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending[typ] = append(e.pending[typ], id)
+}
+
+// Encode serializes msg as a JSON-RPC 2.0 frame.
+func (e *JSONRPCEncoder) Encode(msg Message) ([]byte, error) {
+	method, ok := jsonRPCMethods[msg.Type]
+	if !ok {
+		return nil, errors.Errorf("jsonrpc encode: unknown message type: %s", msg.Type)
+	}
+
+	params, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return nil, errors.Annotate(err, "jsonrpc encode: marshal params")
+	}
+
+	id, isReply := e.dequeueReply(msg.Type)
+
+	if isReply {
+		return json.Marshal(jsonRPCFrame{
+			JSONRPC: jsonRPCVersion,
+			ID:      &id,
+			Result:  params,
+		})
+	}
+
+	return json.Marshal(jsonRPCFrame{
+		JSONRPC: jsonRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// EncodeError serializes an error reply to the request with id as a
+// JSON-RPC 2.0 error frame. Unlike Encode, the id is the caller's to
+// provide directly, since an error reply does not always correspond to a
+// buffered Message of a known Type (e.g. "method not found" before the
+// request could even be decoded into one).
+func (e *JSONRPCEncoder) EncodeError(id uint64, code int, message string) ([]byte, error) {
+	data, err := json.Marshal(jsonRPCFrame{
+		JSONRPC: jsonRPCVersion,
+		ID:      &id,
+		Error:   &jsonRPCError{Code: code, Message: message},
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "jsonrpc encode error")
+	}
+
+	return data, nil
+}
+
+// dequeueReply pops and returns the oldest id awaiting a reply of typ, if
+// any.
+func (e *JSONRPCEncoder) dequeueReply(typ Type) (id uint64, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ids := e.pending[typ]
+	if len(ids) == 0 {
+		return 0, false
+	}
+
+	id, ok = ids[0], true
+
+	if len(ids) == 1 {
+		delete(e.pending, typ)
+	} else {
+		e.pending[typ] = ids[1:]
+	}
+
+	return id, ok
+}
+
+// JSONRPCDecoder decodes JSON-RPC 2.0 request frames into Messages. The
+// request id, if any, is returned alongside the Message so the caller can
+// route a reply back through JSONRPCEncoder.AwaitReply; JSONRPCDecoder
+// itself holds no state across calls.
+type JSONRPCDecoder struct{}
+
+// NewJSONRPCDecoder creates a new JSONRPCDecoder.
+func NewJSONRPCDecoder() *JSONRPCDecoder {
+	return &JSONRPCDecoder{}
+}
+
+// Decode parses a JSON-RPC 2.0 request frame and returns the equivalent
+// Message along with the request id, if any, so the caller can later
+// correlate a reply via JSONRPCEncoder.AwaitReply.
+func (d *JSONRPCDecoder) Decode(data []byte) (Message, *uint64, error) {
+	var frame jsonRPCFrame
+
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return Message{}, nil, errors.Annotate(err, "jsonrpc decode")
+	}
+
+	if frame.JSONRPC != jsonRPCVersion {
+		return Message{}, nil, errors.Trace(ErrJSONRPCVersion)
+	}
+
+	typ, ok := jsonRPCTypes[frame.Method]
+	if !ok {
+		return Message{}, nil, errors.Errorf("jsonrpc decode: unknown method: %s", frame.Method)
+	}
+
+	var payload interface{}
+	if len(frame.Params) > 0 {
+		if err := json.Unmarshal(frame.Params, &payload); err != nil {
+			return Message{}, nil, errors.Annotate(err, "jsonrpc decode: unmarshal params")
+		}
+	}
+
+	return Message{Type: typ, Payload: payload}, frame.ID, nil
+}
@@ -0,0 +1,100 @@
+package message_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRPCEncoder_notification(t *testing.T) {
+	enc := message.NewJSONRPCEncoder()
+
+	msg := message.NewReady("room1", message.Ready{Nickname: "test"})
+
+	data, err := enc.Encode(msg)
+	require.NoError(t, err)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &frame))
+
+	assert.Equal(t, "2.0", frame["jsonrpc"])
+	assert.Equal(t, "ready", frame["method"])
+	assert.Nil(t, frame["id"])
+}
+
+func TestJSONRPCEncoder_reply(t *testing.T) {
+	enc := message.NewJSONRPCEncoder()
+	enc.AwaitReply(message.TypeReady, 42)
+
+	msg := message.NewReady("room1", message.Ready{Nickname: "test"})
+
+	data, err := enc.Encode(msg)
+	require.NoError(t, err)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &frame))
+
+	assert.Equal(t, float64(42), frame["id"])
+	assert.NotNil(t, frame["result"])
+	assert.Nil(t, frame["method"])
+}
+
+func TestJSONRPCEncoder_reply_queuesSameTypeInFlight(t *testing.T) {
+	enc := message.NewJSONRPCEncoder()
+	enc.AwaitReply(message.TypeSignal, 1)
+	enc.AwaitReply(message.TypeSignal, 2)
+
+	msg := message.Message{Type: message.TypeSignal}
+
+	first, err := enc.Encode(msg)
+	require.NoError(t, err)
+
+	second, err := enc.Encode(msg)
+	require.NoError(t, err)
+
+	var firstFrame, secondFrame map[string]interface{}
+	require.NoError(t, json.Unmarshal(first, &firstFrame))
+	require.NoError(t, json.Unmarshal(second, &secondFrame))
+
+	assert.Equal(t, float64(1), firstFrame["id"])
+	assert.Equal(t, float64(2), secondFrame["id"])
+}
+
+func TestJSONRPCEncoder_encodeError(t *testing.T) {
+	enc := message.NewJSONRPCEncoder()
+
+	data, err := enc.EncodeError(7, 404, "method not found")
+	require.NoError(t, err)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &frame))
+
+	assert.Equal(t, float64(7), frame["id"])
+
+	errFrame, ok := frame["error"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(404), errFrame["code"])
+	assert.Equal(t, "method not found", errFrame["message"])
+}
+
+func TestJSONRPCDecoder(t *testing.T) {
+	dec := message.NewJSONRPCDecoder()
+
+	raw := `{"jsonrpc":"2.0","id":1,"method":"ready","params":{"nickname":"test"}}`
+
+	msg, id, err := dec.Decode([]byte(raw))
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, uint64(1), *id)
+	assert.Equal(t, message.TypeReady, msg.Type)
+}
+
+func TestJSONRPCDecoder_badVersion(t *testing.T) {
+	dec := message.NewJSONRPCDecoder()
+
+	_, _, err := dec.Decode([]byte(`{"jsonrpc":"1.0","method":"ready"}`))
+	assert.ErrorIs(t, err, message.ErrJSONRPCVersion)
+}
@@ -0,0 +1,76 @@
+package message
+
+import "github.com/juju/errors"
+
+// JSONRPCSubprotocol is the WebSocket subprotocol name a client offers
+// during the upgrade handshake to ask the server to speak JSON-RPC 2.0 for
+// the lifetime of the connection, instead of the bespoke envelope format.
+const JSONRPCSubprotocol = "peercalls.jsonrpc.v2"
+
+// NegotiateSubprotocol picks JSONRPCSubprotocol out of the subprotocols a
+// client offered during the WebSocket upgrade. ok is false if the client
+// did not offer it, meaning the connection should fall back to the default
+// codec.
+func NegotiateSubprotocol(offered []string) (protocol string, ok bool) {
+	for _, p := range offered {
+		if p == JSONRPCSubprotocol {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// RawWriter writes an already-encoded frame to a client's underlying
+// connection (e.g. a WebSocket). It is the minimal seam JSONRPCWriter needs
+// so it can be tested without a real connection.
+type RawWriter interface {
+	WriteRaw(data []byte) error
+}
+
+// JSONRPCWriter adapts a RawWriter to the server.Writer interface by
+// encoding every Message as a JSON-RPC 2.0 frame before writing it. A
+// Client constructed with one of these in place of the default writer
+// speaks JSON-RPC end-to-end, selected once at handshake time via
+// NegotiateSubprotocol rather than per-message.
+type JSONRPCWriter struct {
+	raw RawWriter
+	enc *JSONRPCEncoder
+}
+
+// NewJSONRPCWriter creates a JSONRPCWriter that encodes onto raw.
+func NewJSONRPCWriter(raw RawWriter) *JSONRPCWriter {
+	return &JSONRPCWriter{
+		raw: raw,
+		enc: NewJSONRPCEncoder(),
+	}
+}
+
+// Write implements server.Writer by encoding msg as a JSON-RPC 2.0 frame
+// and writing it to the underlying connection.
+func (w *JSONRPCWriter) Write(msg Message) error {
+	data, err := w.enc.Encode(msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(w.raw.WriteRaw(data))
+}
+
+// AwaitReply records that a request with id is awaiting a reply of typ, so
+// the next Write of that type is sent as a JSON-RPC result instead of a
+// notification. See JSONRPCEncoder.AwaitReply.
+func (w *JSONRPCWriter) AwaitReply(typ Type, id uint64) {
+	w.enc.AwaitReply(typ, id)
+}
+
+// WriteError encodes and writes a JSON-RPC error reply to the request with
+// id.
+func (w *JSONRPCWriter) WriteError(id uint64, code int, message string) error {
+	data, err := w.enc.EncodeError(id, code, message)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(w.raw.WriteRaw(data))
+}
@@ -0,0 +1,53 @@
+package message_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRawWriter struct {
+	frames [][]byte
+}
+
+func (w *fakeRawWriter) WriteRaw(data []byte) error {
+	w.frames = append(w.frames, data)
+
+	return nil
+}
+
+func TestNegotiateSubprotocol(t *testing.T) {
+	protocol, ok := message.NegotiateSubprotocol([]string{"other", message.JSONRPCSubprotocol})
+	assert.True(t, ok)
+	assert.Equal(t, message.JSONRPCSubprotocol, protocol)
+
+	_, ok = message.NegotiateSubprotocol([]string{"other"})
+	assert.False(t, ok)
+}
+
+func TestJSONRPCWriter_Write(t *testing.T) {
+	raw := &fakeRawWriter{}
+	w := message.NewJSONRPCWriter(raw)
+
+	require.NoError(t, w.Write(message.NewReady("room1", message.Ready{Nickname: "test"})))
+	require.Len(t, raw.frames, 1)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw.frames[0], &frame))
+	assert.Equal(t, "ready", frame["method"])
+}
+
+func TestJSONRPCWriter_WriteError(t *testing.T) {
+	raw := &fakeRawWriter{}
+	w := message.NewJSONRPCWriter(raw)
+
+	require.NoError(t, w.WriteError(3, 500, "boom"))
+	require.Len(t, raw.frames, 1)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw.frames[0], &frame))
+	assert.Equal(t, float64(3), frame["id"])
+}
@@ -0,0 +1,51 @@
+package message
+
+import "github.com/peer-calls/peer-calls/server/identifiers"
+
+// Type identifies the kind of payload a Message carries. JSONRPCEncoder/
+// Decoder map it to a JSON-RPC method name via jsonRPCMethods, which is not
+// always the same string (TypeRoomJoin is sent as "room.join").
+type Type string
+
+const (
+	TypeReady    Type = "ready"
+	TypeRoomJoin Type = "users"
+	TypeHangUp   Type = "hangUp"
+	TypeSignal   Type = "signal"
+	TypePing     Type = "ping"
+)
+
+// Message is the envelope every signaling event is delivered in.
+type Message struct {
+	Type    Type               `json:"type"`
+	Room    identifiers.RoomID `json:"room,omitempty"`
+	Payload interface{}        `json:"payload,omitempty"`
+
+	// Seq is the per-room sequence number an Adapter stamps on every
+	// outbound Message, once per Emit/Broadcast call, before fanning it out
+	// to every recipient, so a reconnecting client can resume from the last
+	// one it saw. It is zero until an Adapter stamps it.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Ready is the payload of a TypeReady message.
+type Ready struct {
+	Nickname string `json:"nickname"`
+}
+
+// RoomJoin is the payload of a TypeRoomJoin message, announcing a member of
+// the room.
+type RoomJoin struct {
+	ClientID identifiers.ClientID `json:"clientId"`
+	Metadata string               `json:"metadata"`
+}
+
+// NewReady creates a TypeReady Message for room.
+func NewReady(room identifiers.RoomID, payload Ready) Message {
+	return Message{Type: TypeReady, Room: room, Payload: payload}
+}
+
+// NewRoomJoin creates a TypeRoomJoin Message for room.
+func NewRoomJoin(room identifiers.RoomID, payload RoomJoin) Message {
+	return Message{Type: TypeRoomJoin, Room: room, Payload: payload}
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/nats-io/nats.go"
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/peer-calls/peer-calls/server/message"
+)
+
+// natsClientsBucketPrefix prefixes the name of the KV bucket used to store
+// room membership. Every signaling node that joins a room creates (or
+// reuses) a bucket named after the room so that `Clients()` reflects cluster-wide membership rather than
+// just the clients connected to the local node.
+const natsClientsBucketPrefix = "peercalls_room_"
+
+// natsSeqKey is the KV entry used to hand out the room's sequence number.
+// It lives in the same bucket as membership, since a roomSequencer (an
+// in-process counter, used by MemoryAdapter) isn't enough here: a room can
+// span every node subscribed to its subject, so the counter has to live
+// somewhere all of them already read from, with Update's CAS semantics
+// serializing concurrent increments from different nodes.
+const natsSeqKey = "seq"
+
+// natsPublisher is the subset of *nats.Conn the adapter needs. It exists so
+// tests can substitute an in-process fake instead of requiring a running
+// nats-server.
+type natsPublisher interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error)
+}
+
+// natsKV is the subset of nats.KeyValue the adapter needs, for the same
+// reason natsPublisher stands in for *nats.Conn: so tests can substitute an
+// in-process fake instead of requiring a running nats-server with
+// JetStream.
+type natsKV interface {
+	Get(key string) (nats.KeyValueEntry, error)
+	Put(key string, value []byte) (revision uint64, err error)
+	Update(key string, value []byte, last uint64) (revision uint64, err error)
+	Delete(key string, opts ...nats.DeleteOpt) error
+	Keys(opts ...nats.WatchOpt) ([]string, error)
+}
+
+// NATSAdapter is an Adapter implementation that stores room membership in a
+// NATS JetStream KV bucket and fans Emit/Broadcast messages out to every
+// signaling node subscribed to the room's subject, so a single room can span
+// many frontends behind a load balancer without sticky sessions. The bucket
+// is the single source of truth about who is in the room; the local clients
+// map below is only used to deliver messages to sockets held open by this
+// node.
+type NATSAdapter struct {
+	room identifiers.RoomID
+	log  logger.Service
+
+	nc natsPublisher
+	kv natsKV
+
+	subject string
+	sub     *nats.Subscription
+
+	mu      sync.RWMutex
+	clients map[identifiers.ClientID]*Client
+}
+
+// NewNATSAdapter creates a new NATSAdapter for room, backed by nc. The
+// adapter subscribes to a room-scoped subject immediately so Broadcast and
+// Emit calls made from other nodes are delivered to clients connected here.
+// A nil log defaults to logger.NewNop().
+func NewNATSAdapter(room identifiers.RoomID, nc *nats.Conn, log logger.Service) (*NATSAdapter, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, errors.Annotate(err, "nats adapter: jetstream")
+	}
+
+	bucket := natsClientsBucketPrefix + string(room)
+
+	kv, err := js.KeyValue(bucket)
+	if errors.Cause(err) == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: bucket})
+	}
+
+	if err != nil {
+		return nil, errors.Annotate(err, "nats adapter: key value")
+	}
+
+	return newNATSAdapter(room, nc, kv, log)
+}
+
+// newNATSAdapter builds a NATSAdapter on top of an already-resolved
+// natsPublisher and KV store, so tests can inject a fake in-process
+// publisher/subscriber without a running nats-server or JetStream.
+func newNATSAdapter(room identifiers.RoomID, nc natsPublisher, kv natsKV, log logger.Service) (*NATSAdapter, error) {
+	if log == nil {
+		log = logger.NewNop()
+	}
+
+	log = log.With(logger.String("room", string(room)))
+
+	a := &NATSAdapter{
+		room:    room,
+		log:     log,
+		nc:      nc,
+		kv:      kv,
+		subject: "peercalls.rooms." + string(room),
+		clients: map[identifiers.ClientID]*Client{},
+	}
+
+	sub, err := nc.Subscribe(a.subject, a.handleRemoteMessage)
+	if err != nil {
+		return nil, errors.Annotate(err, "nats adapter: subscribe")
+	}
+
+	a.sub = sub
+
+	log.Debug("subscribed to room subject", logger.String("subject", a.subject))
+
+	return a, nil
+}
+
+// natsEnvelope wraps a message so remote nodes can tell apart fan-out
+// traffic meant for a single client from room-wide broadcasts.
+type natsEnvelope struct {
+	ClientID identifiers.ClientID `json:"clientId,omitempty"`
+	Message  message.Message      `json:"message"`
+}
+
+func (a *NATSAdapter) handleRemoteMessage(msg *nats.Msg) {
+	var env natsEnvelope
+
+	if err := json.Unmarshal(msg.Data, &env); err != nil {
+		return
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if env.ClientID != "" {
+		if client, ok := a.clients[env.ClientID]; ok {
+			client.Write(env.Message)
+		}
+
+		return
+	}
+
+	for _, client := range a.clients {
+		client.Write(env.Message)
+	}
+}
+
+// nextSeq atomically increments and returns the room's sequence number,
+// stored as the 8 big-endian bytes of a uint64 under natsSeqKey. Update's
+// revision argument is the CAS: it only takes effect if natsSeqKey is still
+// on the revision we just read (0, with no prior entry, counts as not
+// existing yet), so a concurrent increment from another node fails our
+// Update and we retry with its value instead of silently overwriting it.
+func (a *NATSAdapter) nextSeq() (uint64, error) {
+	for {
+		var (
+			seq      uint64
+			revision uint64
+		)
+
+		entry, err := a.kv.Get(natsSeqKey)
+
+		switch {
+		case err == nil:
+			seq = binary.BigEndian.Uint64(entry.Value())
+			revision = entry.Revision()
+		case errors.Cause(err) == nats.ErrKeyNotFound:
+			// seq and revision stay zero.
+		default:
+			return 0, errors.Annotate(err, "next seq: get")
+		}
+
+		seq++
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, seq)
+
+		if _, err := a.kv.Update(natsSeqKey, value, revision); err != nil {
+			if errors.Is(err, nats.ErrKeyExists) {
+				continue
+			}
+
+			return 0, errors.Annotate(err, "next seq: update")
+		}
+
+		return seq, nil
+	}
+}
+
+// Add registers client as a local member of the room, publishes it to the KV
+// store so remote nodes can see it, and broadcasts a RoomJoin announcement
+// for client to every member (local and remote), client included, the same
+// way MemoryAdapter does.
+func (a *NATSAdapter) Add(client *Client) error {
+	clientID := client.ID()
+
+	a.mu.Lock()
+	a.clients[clientID] = client
+	a.mu.Unlock()
+
+	key := fmt.Sprintf("clients/%s", clientID)
+
+	if _, err := a.kv.Put(key, []byte(client.Metadata())); err != nil {
+		return errors.Annotatef(err, "nats adapter: add client: %s", clientID)
+	}
+
+	a.log.Info("client added", logger.String("clientId", string(clientID)))
+
+	return errors.Trace(a.Broadcast(message.NewRoomJoin(a.room, message.RoomJoin{
+		ClientID: clientID,
+		Metadata: client.Metadata(),
+	})))
+}
+
+// Remove removes clientID from the local client set and from the KV store.
+func (a *NATSAdapter) Remove(clientID identifiers.ClientID) error {
+	a.mu.Lock()
+	delete(a.clients, clientID)
+	a.mu.Unlock()
+
+	err := a.kv.Delete(fmt.Sprintf("clients/%s", clientID))
+	if err != nil {
+		return errors.Annotatef(err, "nats adapter: remove client: %s", clientID)
+	}
+
+	a.log.Info("client removed", logger.String("clientId", string(clientID)))
+
+	return nil
+}
+
+// Metadata returns the metadata of clientID known to the cluster, or
+// ok=false if the client is not a member of the room.
+func (a *NATSAdapter) Metadata(clientID identifiers.ClientID) (string, bool) {
+	entry, err := a.kv.Get(fmt.Sprintf("clients/%s", clientID))
+	if err != nil {
+		return "", false
+	}
+
+	return string(entry.Value()), true
+}
+
+// Clients returns the cluster-wide membership of the room as stored in the
+// KV store, not just the clients connected to this node.
+func (a *NATSAdapter) Clients() (map[identifiers.ClientID]string, error) {
+	keys, err := a.kv.Keys()
+	if err != nil && errors.Cause(err) != nats.ErrNoKeysFound {
+		return nil, errors.Annotate(err, "nats adapter: clients")
+	}
+
+	clientIDs := map[identifiers.ClientID]string{}
+
+	for _, key := range keys {
+		// The bucket also holds natsSeqKey, which is not a member.
+		if !strings.HasPrefix(key, "clients/") {
+			continue
+		}
+
+		entry, err := a.kv.Get(key)
+		if err != nil {
+			continue
+		}
+
+		clientID := identifiers.ClientID(strings.TrimPrefix(key, "clients/"))
+		clientIDs[clientID] = string(entry.Value())
+	}
+
+	return clientIDs, nil
+}
+
+// Size returns the cluster-wide number of clients in the room.
+func (a *NATSAdapter) Size() (int, error) {
+	clientIDs, err := a.Clients()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	return len(clientIDs), nil
+}
+
+// Emit delivers msg to clientID, whether it is connected to this node or a
+// remote one, stamped with the room's cluster-wide next sequence number.
+func (a *NATSAdapter) Emit(clientID identifiers.ClientID, msg message.Message) error {
+	seq, err := a.nextSeq()
+	if err != nil {
+		return errors.Annotate(err, "nats adapter: emit")
+	}
+
+	msg.Seq = seq
+
+	a.mu.RLock()
+	client, ok := a.clients[clientID]
+	a.mu.RUnlock()
+
+	if ok {
+		client.Write(msg)
+
+		return nil
+	}
+
+	data, err := json.Marshal(natsEnvelope{ClientID: clientID, Message: msg})
+	if err != nil {
+		return errors.Annotate(err, "nats adapter: emit: marshal")
+	}
+
+	if err := a.nc.Publish(a.subject, data); err != nil {
+		return errors.Annotatef(err, "nats adapter: emit: %s", clientID)
+	}
+
+	return nil
+}
+
+// Broadcast delivers msg to every member of the room, local and remote,
+// stamped with the room's cluster-wide next sequence number before
+// publishing. It only publishes to the room subject; handleRemoteMessage is
+// the single path that writes to locally-connected clients, since NATS
+// echoes a publisher's own message back to its own subscriptions. Writing
+// to local clients here too would deliver msg to them twice.
+func (a *NATSAdapter) Broadcast(msg message.Message) error {
+	seq, err := a.nextSeq()
+	if err != nil {
+		return errors.Annotate(err, "nats adapter: broadcast")
+	}
+
+	msg.Seq = seq
+
+	data, err := json.Marshal(natsEnvelope{Message: msg})
+	if err != nil {
+		return errors.Annotate(err, "nats adapter: broadcast: marshal")
+	}
+
+	if err := a.nc.Publish(a.subject, data); err != nil {
+		return errors.Annotate(err, "nats adapter: broadcast")
+	}
+
+	return nil
+}
+
+// Close unsubscribes from the room subject. It does not remove the room's
+// KV bucket since other nodes may still be serving clients in it.
+func (a *NATSAdapter) Close() error {
+	if err := a.sub.Unsubscribe(); err != nil {
+		return errors.Annotate(err, "nats adapter: close")
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATSConn is an in-process, synchronous stand-in for *nats.Conn that
+// only implements Publish/Subscribe, enough to drive a NATSAdapter without
+// a running nats-server. It mimics the one bit of real NATS behaviour this
+// test cares about: a publisher's own message is echoed back to its own
+// subscriptions.
+type fakeNATSConn struct {
+	mu       sync.Mutex
+	handlers map[string][]nats.MsgHandler
+}
+
+func newFakeNATSConn() *fakeNATSConn {
+	return &fakeNATSConn{handlers: map[string][]nats.MsgHandler{}}
+}
+
+func (f *fakeNATSConn) Publish(subject string, data []byte) error {
+	f.mu.Lock()
+	handlers := append([]nats.MsgHandler(nil), f.handlers[subject]...)
+	f.mu.Unlock()
+
+	for _, h := range handlers {
+		h(&nats.Msg{Subject: subject, Data: data})
+	}
+
+	return nil
+}
+
+func (f *fakeNATSConn) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	f.mu.Lock()
+	f.handlers[subject] = append(f.handlers[subject], cb)
+	f.mu.Unlock()
+
+	return &nats.Subscription{}, nil
+}
+
+// fakeKVEntry is the minimal nats.KeyValueEntry fakeKV.Get returns: only
+// Value and Revision carry real data, since those are all nextSeq reads.
+type fakeKVEntry struct {
+	value    []byte
+	revision uint64
+}
+
+func (e *fakeKVEntry) Bucket() string             { return "" }
+func (e *fakeKVEntry) Key() string                { return "" }
+func (e *fakeKVEntry) Value() []byte              { return e.value }
+func (e *fakeKVEntry) Revision() uint64           { return e.revision }
+func (e *fakeKVEntry) Created() time.Time         { return time.Time{} }
+func (e *fakeKVEntry) Delta() uint64              { return 0 }
+func (e *fakeKVEntry) Operation() nats.KeyValueOp { return nats.KeyValuePut }
+
+// fakeKV is an in-process, synchronous stand-in for nats.KeyValue that
+// implements enough of natsKV's CAS semantics (Get/Update's
+// expected-last-revision check) to drive NATSAdapter.nextSeq without a
+// running nats-server.
+type fakeKV struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	revision map[string]uint64
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{values: map[string][]byte{}, revision: map[string]uint64{}}
+}
+
+func (f *fakeKV) Get(key string) (nats.KeyValueEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value, ok := f.values[key]
+	if !ok {
+		return nil, nats.ErrKeyNotFound
+	}
+
+	return &fakeKVEntry{value: value, revision: f.revision[key]}, nil
+}
+
+func (f *fakeKV) Put(key string, value []byte) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.values[key] = value
+	f.revision[key]++
+
+	return f.revision[key], nil
+}
+
+func (f *fakeKV) Update(key string, value []byte, last uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.revision[key] != last {
+		return 0, nats.ErrKeyExists
+	}
+
+	f.values[key] = value
+	f.revision[key]++
+
+	return f.revision[key], nil
+}
+
+func (f *fakeKV) Delete(key string, opts ...nats.DeleteOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.values, key)
+	delete(f.revision, key)
+
+	return nil
+}
+
+func (f *fakeKV) Keys(opts ...nats.WatchOpt) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.values))
+	for key := range f.values {
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// countingWriter counts how many times Write is called, instead of
+// serializing anything, since this test only cares about delivery counts.
+type countingWriter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (w *countingWriter) Write(message.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.count++
+
+	return nil
+}
+
+func (w *countingWriter) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.count
+}
+
+func TestNATSAdapter_Broadcast_deliversOncePerLocalClient(t *testing.T) {
+	conn := newFakeNATSConn()
+
+	adapter, err := newNATSAdapter(identifiers.RoomID("room1"), conn, newFakeKV(), logger.NewNop())
+	require.NoError(t, err)
+
+	writer := &countingWriter{}
+	client := NewClient(writer, logger.NewNop())
+
+	adapter.mu.Lock()
+	adapter.clients[client.ID()] = client
+	adapter.mu.Unlock()
+
+	require.NoError(t, adapter.Broadcast(message.NewReady("room1", message.Ready{Nickname: "test"})))
+
+	assert.Equal(t, 1, writer.Count(), "client should be written to exactly once per Broadcast")
+
+	// Add's RoomJoin announcement goes through the same Broadcast method, so
+	// the same single-delivery guarantee applies to it too.
+}
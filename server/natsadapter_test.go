@@ -0,0 +1,61 @@
+package server_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/peer-calls/peer-calls/server"
+	"github.com/peer-calls/peer-calls/server/identifiers"
+	"github.com/peer-calls/peer-calls/server/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectNATS skips the test unless PEERCALLS_TEST_NATS_URL points at a
+// running nats-server with JetStream enabled, since the adapter needs a
+// real KV store to exercise cluster-wide membership.
+func connectNATS(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	url := os.Getenv("PEERCALLS_TEST_NATS_URL")
+	if url == "" {
+		t.Skip("PEERCALLS_TEST_NATS_URL not set, skipping NATS adapter test")
+	}
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+
+	t.Cleanup(nc.Close)
+
+	return nc
+}
+
+func TestNATSAdapter_add_remove_clients(t *testing.T) {
+	nc := connectNATS(t)
+
+	adapter, err := server.NewNATSAdapter(room, nc, logger.NewNop())
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	mockWriter := NewMockWriter()
+	client := server.NewClient(mockWriter, logger.NewNop())
+	client.SetMetadata("a")
+	clientID := client.ID()
+
+	require.NoError(t, adapter.Add(client))
+
+	clientIDs, err := adapter.Clients()
+	require.NoError(t, err)
+	assert.Equal(t, map[identifiers.ClientID]string{clientID: "a"}, clientIDs)
+
+	size, err := adapter.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	require.NoError(t, adapter.Remove(clientID))
+
+	clientIDs, err = adapter.Clients()
+	require.NoError(t, err)
+	assert.Equal(t, map[identifiers.ClientID]string{}, clientIDs)
+}
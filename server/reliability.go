@@ -0,0 +1,206 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/peer-calls/peer-calls/server/message"
+)
+
+// replayWindowSize is the number of recently sent messages each Client
+// keeps buffered so a reconnecting client can resume from the last
+// sequence number it acked, rather than re-joining and missing traffic
+// that happened while its socket was down.
+const replayWindowSize = 1024
+
+// seqEntry is one buffered, already-sequenced outbound message.
+type seqEntry struct {
+	seq uint64
+	msg message.Message
+}
+
+// replayBuffer is a fixed-size ring buffer of the most recently sent
+// messages for one client, indexed by sequence number so a `resume{lastSeq}`
+// frame can be turned into a `(lastSeq, current]` replay slice. The
+// sequence numbers themselves come from the room's roomSequencer, not from
+// this buffer: replayBuffer only ever records entries it's handed.
+//
+// replayBuffer is not safe for concurrent use; callers must hold the
+// owning resumer's lock.
+type replayBuffer struct {
+	entries []seqEntry
+	last    uint64 // seq of the most recently recorded entry, 0 if none yet.
+	acked   uint64
+}
+
+func newReplayBuffer() *replayBuffer {
+	return &replayBuffer{
+		entries: make([]seqEntry, 0, replayWindowSize),
+	}
+}
+
+// record appends msg, already stamped with its room sequence number, to the
+// buffer.
+func (b *replayBuffer) record(msg message.Message) seqEntry {
+	entry := seqEntry{seq: msg.Seq, msg: msg}
+
+	b.entries = append(b.entries, entry)
+	if len(b.entries) > replayWindowSize {
+		b.entries = b.entries[len(b.entries)-replayWindowSize:]
+	}
+
+	b.last = entry.seq
+
+	return entry
+}
+
+// since returns every buffered entry with seq > lastSeq, in order. It
+// returns ok=false if lastSeq is below the oldest buffered entry's seq minus
+// one, meaning the gap cannot be closed and the client must be reset
+// instead of replayed to.
+func (b *replayBuffer) since(lastSeq uint64) ([]seqEntry, bool) {
+	if len(b.entries) == 0 {
+		return nil, lastSeq == b.last
+	}
+
+	oldest := b.entries[0].seq
+
+	if lastSeq < oldest-1 {
+		return nil, false
+	}
+
+	for i, entry := range b.entries {
+		if entry.seq > lastSeq {
+			return b.entries[i:], true
+		}
+	}
+
+	return nil, true
+}
+
+// ack records that the client has seen every message up to seq and drops
+// buffered entries at or below it.
+func (b *replayBuffer) ack(seq uint64) {
+	b.acked = seq
+
+	i := 0
+	for ; i < len(b.entries); i++ {
+		if b.entries[i].seq > seq {
+			break
+		}
+	}
+
+	b.entries = b.entries[i:]
+}
+
+// unackedBeyondWindow reports whether the client has fallen more than
+// replayWindowSize messages behind without acking, in which case it can no
+// longer be resumed and must be forcibly reset.
+func (b *replayBuffer) unackedBeyondWindow() bool {
+	return b.last-b.acked > replayWindowSize
+}
+
+// roomSequencer hands out a monotonically increasing, per-room sequence
+// number. MemoryAdapter owns exactly one and calls next once per Emit/
+// Broadcast call, stamping the result onto the Message before fanning it
+// out, so every recipient of that call sees the same Seq (NATSAdapter keeps
+// its counter in the KV store instead, see NATSAdapter.nextSeq) — unlike stamping in Client.Write, which would give every client its
+// own independent counter for what is supposed to be the same event.
+type roomSequencer struct {
+	mu  sync.Mutex
+	seq uint64
+}
+
+func newRoomSequencer() *roomSequencer {
+	return &roomSequencer{}
+}
+
+// next increments the sequence and calls fn with the result, holding the
+// lock for the duration of fn. An Adapter's Emit/Broadcast must stamp and
+// fan out to every recipient inside fn: if the fan-out happened after next
+// returned, a concurrent call could stamp and fan out first, and a client
+// reached by both would record the two in reverse order in its
+// replayBuffer, which assumes entries arrive in ascending seq order.
+func (s *roomSequencer) next(fn func(seq uint64)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+
+	fn(s.seq)
+}
+
+// ResumeRequest is sent by a reconnecting client to ask the server to
+// replay every message after lastSeq before resuming live traffic.
+type ResumeRequest struct {
+	LastSeq uint64 `json:"lastSeq"`
+}
+
+// Ack is sent periodically by a client to report the highest sequence
+// number it has seen, so the server can drop buffered entries it no longer
+// needs to keep around for replay.
+type Ack struct {
+	Seq uint64 `json:"seq"`
+}
+
+// resumer is embedded by Client (see Client.Write, Client.Resume and
+// Client.Ack) to add buffering and replay on top of the plain
+// Subscribe/Write loop, without changing the ordering of messages on the
+// happy path where no reconnect happens. It does not generate sequence
+// numbers itself — those come from the room's roomSequencer and arrive
+// already stamped on msg — it only remembers what was sent so a
+// reconnecting client can be replayed to.
+type resumer struct {
+	mu     sync.Mutex
+	buffer *replayBuffer
+}
+
+func newResumer() *resumer {
+	return &resumer{buffer: newReplayBuffer()}
+}
+
+// record buffers msg, already stamped with its room sequence number, for
+// future replay.
+func (r *resumer) record(msg message.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buffer.record(msg)
+}
+
+// resume returns the messages that should be replayed for a client that
+// reconnected with req.LastSeq already seen. ok is false if the gap is too
+// large to close from the buffer, meaning the caller must force the client
+// to reset instead (e.g. drop it back to a fresh room join).
+func (r *resumer) resume(req ResumeRequest) (msgs []message.Message, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, ok := r.buffer.since(req.LastSeq)
+	if !ok {
+		return nil, false
+	}
+
+	msgs = make([]message.Message, len(entries))
+	for i, entry := range entries {
+		msgs[i] = entry.msg
+	}
+
+	return msgs, true
+}
+
+// ack records an Ack received from the client.
+func (r *resumer) ack(a Ack) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buffer.ack(a.Seq)
+}
+
+// shouldReset reports whether the client has gone unacked for longer than
+// the replay window allows and must be forcibly reset.
+func (r *resumer) shouldReset() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.buffer.unackedBeyondWindow()
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/peer-calls/peer-calls/server/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomSequencer_next(t *testing.T) {
+	s := newRoomSequencer()
+
+	var got []uint64
+
+	s.next(func(seq uint64) { got = append(got, seq) })
+	s.next(func(seq uint64) { got = append(got, seq) })
+
+	assert.Equal(t, []uint64{1, 2}, got)
+}
+
+func TestResumer_record_and_resume(t *testing.T) {
+	r := newResumer()
+
+	msg1 := message.Message{Type: message.TypeReady, Seq: 1}
+	msg2 := message.Message{Type: message.TypeReady, Seq: 2}
+
+	r.record(msg1)
+	r.record(msg2)
+
+	replay, ok := r.resume(ResumeRequest{LastSeq: 1})
+	assert.True(t, ok)
+	assert.Equal(t, []message.Message{msg2}, replay)
+}
+
+func TestResumer_ack_drops_buffered_entries(t *testing.T) {
+	r := newResumer()
+
+	r.record(message.Message{Type: message.TypeReady, Seq: 1})
+	r.record(message.Message{Type: message.TypeReady, Seq: 2})
+	r.ack(Ack{Seq: 2})
+
+	assert.Equal(t, 0, len(r.buffer.entries))
+}
+
+func TestResumer_resume_gap_too_large(t *testing.T) {
+	r := newResumer()
+
+	r.record(message.Message{Type: message.TypeReady, Seq: 1})
+	r.ack(Ack{Seq: 1})
+	r.record(message.Message{Type: message.TypeReady, Seq: 2})
+
+	_, ok := r.resume(ResumeRequest{LastSeq: 0})
+	assert.False(t, ok)
+}
+
+func TestResumer_shouldReset(t *testing.T) {
+	r := newResumer()
+
+	for i := 0; i < replayWindowSize+1; i++ {
+		r.record(message.Message{Type: message.TypeReady, Seq: uint64(i + 1)})
+	}
+
+	assert.True(t, r.shouldReset())
+
+	r.ack(Ack{Seq: uint64(replayWindowSize + 1)})
+	assert.False(t, r.shouldReset())
+}